@@ -8,26 +8,243 @@ package tiktoken_go
 #cgo linux LDFLAGS: ${SRCDIR}/tiktoken-cffi/target/release/libtiktoken.a -ldl
 #cgo darwin LDFLAGS: ${SRCDIR}/tiktoken-cffi/target/release/libtiktoken.a -framework Security -framework CoreFoundation
 
+#include <stdbool.h>
 #include <stdlib.h>
 
-extern unsigned int count_tokens(const char*, const char*);
-extern unsigned int get_context_size(const char*);
+extern bool is_model_supported(const char*, size_t);
+extern const char* get_encoding_name(const char*, size_t);
+extern unsigned int count_tokens(const char*, size_t, const char*, size_t);
+extern unsigned int* encode_ordinary(const char*, size_t, const char*, size_t, size_t*);
+extern unsigned int* encode_with_special_tokens(const char*, size_t, const char*, size_t, size_t*);
+extern void free_tokens(unsigned int*, size_t);
+extern int decode(const char*, size_t, const unsigned int*, size_t, char**, size_t*, size_t*);
+extern void free_text(char*, size_t);
 */
 import "C"
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"strings"
+	"sync"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/sashabaranov/go-openai"
 )
 
+// ErrModelNotSupported is wrapped by the errors of functions called with a model that has no tokenizer.
+var ErrModelNotSupported = errors.New("model not supported")
+
+// IsModelSupported reports whether a tokenizer is known for the specified model.
+// When it reports false, functions that return an error return one wrapping ErrModelNotSupported,
+// and the others, such as CountTokens and EncodeOrdinary, abort the process.
+func IsModelSupported(model string) bool {
+	m, mn := stringArg(model)
+	return bool(C.is_model_supported(m, mn))
+}
+
+// EncodingForModel returns the name of the encoding used by the specified model, such as "cl100k_base".
+// It reports false if the model is not supported.
+func EncodingForModel(model string) (string, bool) {
+	m, mn := stringArg(model)
+	name := C.get_encoding_name(m, mn)
+	if name == nil {
+		return "", false
+	}
+	return C.GoString(name), true
+}
+
+// ModelOrDefault returns model if a tokenizer is known for it, and fallback otherwise.
+// It lets callers tokenize new model names with a similar model instead of failing,
+// e.g. CountTokens(ModelOrDefault(model, "gpt-3.5-turbo"), prompt).
+// The fallback is returned without being checked, so it must itself be a supported model:
+// an unsupported fallback makes functions such as CountTokens abort the process.
+func ModelOrDefault(model, fallback string) string {
+	if IsModelSupported(model) {
+		return model
+	}
+	return fallback
+}
+
+// CountTokens returns the number of tokens in prompt for the specified model.
+// Invalid UTF-8 in prompt is replaced with U+FFFD before tokenizing.
 func CountTokens(model, prompt string) int {
-	m := C.CString(model)
-	p := C.CString(prompt)
-	count := C.count_tokens(m, p)
-	C.free(unsafe.Pointer(m))
-	C.free(unsafe.Pointer(p))
-	return int(count)
+	m, mn := stringArg(model)
+	p, pn := stringArg(prompt)
+	return int(C.count_tokens(m, mn, p, pn))
+}
+
+// EstimateTokens quickly estimates the number of tokens in prompt without tokenizing it,
+// assuming about 4 characters per token for ASCII text and one token per other character.
+// It is meant for live feedback such as counting as the user types; use CountTokens for an exact count.
+func EstimateTokens(prompt string) int {
+	var ascii, other int
+	for _, r := range prompt {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// Warmup loads the tokenizers of the specified models concurrently and returns when all are ready.
+// Tokenizers are otherwise loaded on first use, which can add noticeable latency to the first request.
+// Models that are not supported are skipped, since there is no tokenizer to load.
+func Warmup(models ...string) {
+	var wg sync.WaitGroup
+	for _, model := range models {
+		if !IsModelSupported(model) {
+			continue
+		}
+		wg.Add(1)
+		go func(model string) {
+			defer wg.Done()
+			CountTokens(model, "")
+		}(model)
+	}
+	wg.Wait()
+}
+
+// stringArg passes s to the Rust side without copying it.
+// Unlike a C string it needs no allocation and keeps NUL bytes, so the whole prompt is tokenized.
+func stringArg(s string) (*C.char, C.size_t) {
+	return (*C.char)(unsafe.Pointer(unsafe.StringData(s))), C.size_t(len(s))
+}
+
+// EncodeOrdinary returns the token ids of prompt, treating special tokens such as
+// <|endoftext|> as ordinary text. It matches tiktoken's encode_ordinary.
+func EncodeOrdinary(model, prompt string) []int {
+	m, mn := stringArg(model)
+	p, pn := stringArg(prompt)
+	var n C.size_t
+	tokens := C.encode_ordinary(m, mn, p, pn, &n)
+	return takeTokens(tokens, n)
+}
+
+// EncodeWithSpecialTokens returns the token ids of prompt, encoding any special
+// token literal it contains as the corresponding special token.
+// The result has the same length as CountTokens reports.
+func EncodeWithSpecialTokens(model, prompt string) []int {
+	m, mn := stringArg(model)
+	p, pn := stringArg(prompt)
+	var n C.size_t
+	tokens := C.encode_with_special_tokens(m, mn, p, pn, &n)
+	return takeTokens(tokens, n)
+}
+
+// takeTokens copies a token buffer allocated by the Rust side into a Go slice and frees it.
+func takeTokens(tokens *C.uint, n C.size_t) []int {
+	ids := make([]int, int(n))
+	for i, id := range unsafe.Slice(tokens, int(n)) {
+		ids[i] = int(id)
+	}
+	C.free_tokens(tokens, n)
+	return ids
+}
+
+// InvalidTokenError is returned by Decode when a token id is not part of the model's encoding.
+type InvalidTokenError struct {
+	Token    int
+	Position int
+}
+
+func (e *InvalidTokenError) Error() string {
+	return fmt.Sprintf("invalid token %d at position %d", e.Token, e.Position)
+}
+
+// ErrInvalidUTF8 is returned by Decode when the tokens do not form valid UTF-8.
+var ErrInvalidUTF8 = errors.New("tokens do not decode to valid UTF-8")
+
+// Decode returns the text of tokens. All ids are validated before the text is built:
+// an id that is not part of the model's encoding yields an *InvalidTokenError with its position.
+// Decode always validates UTF-8: tokens that do not form valid UTF-8, such as a sequence cut
+// inside a rune, yield ErrInvalidUTF8. There is no lenient mode, because the library only
+// decodes to a checked string and never hands back the raw bytes.
+// Unknown ids are detected by catching a panic in the library; it is not printed to stderr.
+func Decode(model string, tokens []int) (string, error) {
+	if !IsModelSupported(model) {
+		return "", fmt.Errorf("%w: %s", ErrModelNotSupported, model)
+	}
+	ids := make([]C.uint, len(tokens))
+	for i, id := range tokens {
+		if id < 0 || uint64(id) > math.MaxUint32 {
+			return "", &InvalidTokenError{Token: id, Position: i}
+		}
+		ids[i] = C.uint(id)
+	}
+	var idsPtr *C.uint
+	if len(ids) > 0 {
+		idsPtr = &ids[0]
+	}
+
+	m, mn := stringArg(model)
+	var text *C.char
+	var n, position C.size_t
+	switch C.decode(m, mn, idsPtr, C.size_t(len(ids)), &text, &n, &position) {
+	case 0:
+		decoded := C.GoStringN(text, C.int(n))
+		C.free_text(text, n)
+		return decoded, nil
+	case 1:
+		return "", &InvalidTokenError{Token: tokens[position], Position: int(position)}
+	case 2:
+		return "", ErrInvalidUTF8
+	default:
+		return "", fmt.Errorf("decoding %d tokens for model %s failed", len(tokens), model)
+	}
+}
+
+// CanDecode reports whether every id in tokens is part of the model's encoding, so that the
+// tokens could have been produced by one of its models. Ids that only decode to invalid UTF-8,
+// such as a sequence cut inside a rune, still count as decodable. It reports false for an unsupported model.
+func CanDecode(model string, tokens []int) bool {
+	_, err := Decode(model, tokens)
+	return err == nil || errors.Is(err, ErrInvalidUTF8)
+}
+
+// encodingModels lists a model for each encoding, from the smallest vocabulary to the largest.
+var encodingModels = []struct {
+	Encoding string
+	Model    string
+}{
+	{"r50k_base", "davinci"},
+	{"p50k_base", "text-davinci-003"},
+	{"p50k_edit", "text-davinci-edit-001"},
+	{"cl100k_base", "gpt-4"},
+}
+
+// GuessEncoding returns the encodings that contain every id in tokens, smallest vocabulary first.
+// It helps with token ids that were saved without recording the model that produced them.
+func GuessEncoding(tokens []int) []string {
+	var encodings []string
+	for _, e := range encodingModels {
+		if CanDecode(e.Model, tokens) {
+			encodings = append(encodings, e.Encoding)
+		}
+	}
+	return encodings
+}
+
+// DecodeTo decodes tokens like Decode and writes the text to w, returning the number of bytes written.
+// The text is still assembled in full before it is written, since the library decodes a whole sequence at once.
+func DecodeTo(w io.Writer, model string, tokens []int) (int, error) {
+	text, err := Decode(model, tokens)
+	if err != nil {
+		return 0, err
+	}
+	return io.WriteString(w, text)
+}
+
+// DecodeRange decodes tokens[start:end], returning an error instead of panicking when the range is out of bounds.
+func DecodeRange(model string, tokens []int, start, end int) (string, error) {
+	if start < 0 || end < start || end > len(tokens) {
+		return "", fmt.Errorf("token range [%d:%d] out of bounds for length %d", start, end, len(tokens))
+	}
+	return Decode(model, tokens[start:end])
 }
 
 // GetContextSize Returns the context size of a specified model.
@@ -35,18 +252,28 @@ func CountTokens(model, prompt string) int {
 // This function checks the model name and returns the corresponding context size.
 // See <https://platform.openai.com/docs/models> for up-to-date information.
 // It returns a default value of 4096 if the model is not recognized.
+// Its values are kept as they were for existing callers; MaxContextTokens reports current context lengths.
+// Prefixes are matched in order, so more specific prefixes must come first.
+// Model names are matched case-insensitively.
 func GetContextSize(model string) int {
+	model = strings.ToLower(model)
 	switch {
 	case strings.HasPrefix(model, "gpt-4-32k"):
 		return 32768
 	case strings.HasPrefix(model, "gpt-4"):
 		return 8192
-	case strings.HasPrefix(model, "gpt-3.5-turbo"):
+	case strings.HasPrefix(model, "gpt-3.5-turbo-16k"), strings.HasPrefix(model, "gpt-35-turbo-16k"):
+		return 16384
+	case strings.HasPrefix(model, "gpt-3.5-turbo"), strings.HasPrefix(model, "gpt-35-turbo"):
 		return 4096
 	case strings.HasPrefix(model, "text-davinci-002"), strings.HasPrefix(model, "text-davinci-003"):
 		return 4097
 	case strings.HasPrefix(model, "ada"), strings.HasPrefix(model, "babbage"), strings.HasPrefix(model, "curie"):
 		return 2049
+	case strings.HasPrefix(model, "text-embedding-ada-002"), strings.HasPrefix(model, "text-embedding-3-"):
+		return 8191
+	case strings.HasPrefix(model, "text-moderation-"):
+		return 32768
 	case strings.HasPrefix(model, "code-cushman-001"):
 		return 2048
 	case strings.HasPrefix(model, "code-davinci-002"):
@@ -63,14 +290,88 @@ func GetContextSize(model string) int {
 	}
 }
 
+// MaxContextTokens returns the context length of a specified model,
+// or an error if the model is not recognized.
+// See <https://platform.openai.com/docs/models> for up-to-date information.
+// Prefixes are matched in order, so more specific prefixes must come first.
+// Model names are matched case-insensitively.
+func MaxContextTokens(model string) (int, error) {
+	name := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(name, "o1-mini"), strings.HasPrefix(name, "o1-preview"):
+		return 128000, nil
+	case strings.HasPrefix(name, "o1"), strings.HasPrefix(name, "o3"):
+		return 200000, nil
+	case strings.HasPrefix(name, "gpt-4.1"):
+		return 1047576, nil
+	case strings.HasPrefix(name, "gpt-4.5"):
+		return 128000, nil
+	case strings.HasPrefix(name, "gpt-4o"), strings.HasPrefix(name, "gpt-4-turbo"),
+		strings.HasPrefix(name, "gpt-4-1106"), strings.HasPrefix(name, "gpt-4-0125"),
+		strings.HasPrefix(name, "gpt-4-vision"):
+		return 128000, nil
+	case strings.HasPrefix(name, "gpt-4-32k"):
+		return 32768, nil
+	case name == "gpt-4", strings.HasPrefix(name, "gpt-4-"):
+		return 8192, nil
+	case strings.HasPrefix(name, "gpt-3.5-turbo-instruct"), strings.HasPrefix(name, "gpt-35-turbo-instruct"),
+		strings.HasPrefix(name, "gpt-3.5-turbo-0301"), strings.HasPrefix(name, "gpt-35-turbo-0301"),
+		strings.HasPrefix(name, "gpt-3.5-turbo-0613"), strings.HasPrefix(name, "gpt-35-turbo-0613"):
+		return 4096, nil
+	case strings.HasPrefix(name, "gpt-3.5-turbo"), strings.HasPrefix(name, "gpt-35-turbo"):
+		return 16385, nil
+	case strings.HasPrefix(name, "text-davinci-002"), strings.HasPrefix(name, "text-davinci-003"):
+		return 4097, nil
+	case strings.HasPrefix(name, "davinci-002"), strings.HasPrefix(name, "babbage-002"):
+		return 16384, nil
+	case strings.HasPrefix(name, "ada"), strings.HasPrefix(name, "babbage"), strings.HasPrefix(name, "curie"):
+		return 2049, nil
+	case strings.HasPrefix(name, "text-embedding-ada-002"), strings.HasPrefix(name, "text-embedding-3-"):
+		return 8191, nil
+	case strings.HasPrefix(name, "text-moderation-"):
+		return 32768, nil
+	case strings.HasPrefix(name, "code-cushman-001"):
+		return 2048, nil
+	case strings.HasPrefix(name, "code-davinci-002"):
+		return 8001, nil
+	case strings.HasPrefix(name, "davinci"):
+		return 2049, nil
+	case strings.HasPrefix(name, "text-ada-001"), strings.HasPrefix(
+		name,
+		"text-babbage-001",
+	), strings.HasPrefix(name, "text-curie-001"):
+		return 2049, nil
+	default:
+		return 0, fmt.Errorf("unknown context length for model %s", model)
+	}
+}
+
+// RemainingTokens returns how many tokens of the model's context are left after the prompt
+// and a completion of up to maxCompletion tokens. The result is negative when the prompt fits
+// but the completion does not; an error is returned if the prompt alone exceeds the context.
+func RemainingTokens(model, prompt string, maxCompletion int) (int, error) {
+	if !IsModelSupported(model) {
+		return 0, fmt.Errorf("%w: %s", ErrModelNotSupported, model)
+	}
+	size, err := MaxContextTokens(model)
+	if err != nil {
+		return 0, err
+	}
+	count := CountTokens(model, prompt)
+	if count > size {
+		return 0, fmt.Errorf("prompt has %d tokens, exceeding the %d token context of model %s", count, size, model)
+	}
+	return size - count - maxCompletion, nil
+}
+
 // CountMessagesTokens based on https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb
 func CountMessagesTokens(model string, messages []openai.ChatCompletionMessage) int {
 	var tokens int
 	var tokensPerMessage int
 	var tokensPerName int
 
-	switch model {
-	case openai.GPT3Dot5Turbo, openai.GPT3Dot5Turbo0301:
+	switch strings.ToLower(model) {
+	case openai.GPT3Dot5Turbo, openai.GPT3Dot5Turbo0301, "gpt-35-turbo", "gpt-35-turbo-0301":
 		tokensPerMessage = 4 // every message follows <|start|>{role/name}\n{content}<|end|>\n
 		tokensPerName = -1   // if there's a name, the role is omitted
 	case openai.GPT4, openai.GPT40314, openai.GPT432K, openai.GPT432K0314:
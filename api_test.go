@@ -4,8 +4,12 @@ package tiktoken_go
 
 import (
 	"context"
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -19,10 +23,382 @@ func TestCountTokens(t *testing.T) {
 	}
 }
 
+func TestIsModelSupported(t *testing.T) {
+	if !IsModelSupported("gpt-3.5-turbo") {
+		t.Errorf("IsModelSupported(%q) = false, want true", "gpt-3.5-turbo")
+	}
+	if IsModelSupported("no-such-model") {
+		t.Errorf("IsModelSupported(%q) = true, want false", "no-such-model")
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	var testcases = []struct {
+		Model    string
+		Encoding string
+		OK       bool
+	}{
+		{"gpt-4", "cl100k_base", true},
+		{"gpt-3.5-turbo-instruct", "cl100k_base", true},
+		{"gpt-3.5-turbo-instruct-0914", "cl100k_base", true},
+		{"gpt-35-turbo", "cl100k_base", true},
+		{"gpt-35-turbo-0301", "cl100k_base", true},
+		{"gpt-35-turbo-16k", "cl100k_base", true},
+		{"gpt-4-32k", "cl100k_base", true},
+		{"text-embedding-ada-002", "cl100k_base", true},
+		{"text-embedding-ada-002-v2", "cl100k_base", true},
+		{"text-embedding-3-small", "cl100k_base", true},
+		{"text-embedding-3-large", "cl100k_base", true},
+		{"text-moderation-latest", "cl100k_base", true},
+		{"text-moderation-stable", "cl100k_base", true},
+		{"text-moderation-007", "cl100k_base", true},
+		{"text-davinci-003", "p50k_base", true},
+		{"text-davinci-edit-001", "p50k_edit", true},
+		{"davinci", "r50k_base", true},
+		{"GPT-4", "cl100k_base", true},
+		{"Gpt-3.5-Turbo", "cl100k_base", true},
+		{"TEXT-EMBEDDING-3-SMALL", "cl100k_base", true},
+		{"GPT-35-TURBO", "cl100k_base", true},
+		{"no-such-model", "", false},
+	}
+
+	for _, tc := range testcases {
+		encoding, ok := EncodingForModel(tc.Model)
+		if encoding != tc.Encoding || ok != tc.OK {
+			t.Errorf("EncodingForModel(%q) = %v, %v, want %v, %v", tc.Model, encoding, ok, tc.Encoding, tc.OK)
+		}
+	}
+}
+
+func TestModelOrDefault(t *testing.T) {
+	var testcases = []struct {
+		Model string
+		Want  string
+	}{
+		{"gpt-4", "gpt-4"},
+		{"no-such-model", "gpt-3.5-turbo"},
+		// gpt-4o uses o200k_base, which is not available in this binding.
+		{"gpt-4o", "gpt-3.5-turbo"},
+	}
+
+	for _, tc := range testcases {
+		if got := ModelOrDefault(tc.Model, "gpt-3.5-turbo"); got != tc.Want {
+			t.Errorf("ModelOrDefault(%q) = %v, want %v", tc.Model, got, tc.Want)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	var testcases = []struct {
+		Prompt string
+		Count  int
+	}{
+		{"", 0},
+		{"hello world", 3},
+		{"The quick brown fox jumps over the lazy dog.", 11},
+		{"你好世界", 4},
+	}
+
+	for _, tc := range testcases {
+		if count := EstimateTokens(tc.Prompt); count != tc.Count {
+			t.Errorf("EstimateTokens(%q) = %v, want %v", tc.Prompt, count, tc.Count)
+		}
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	Warmup("gpt-3.5-turbo", "text-davinci-003", "code-davinci-edit-001", "davinci", "no-such-model")
+	if count := CountTokens("text-davinci-003", "hello world"); count != 2 {
+		t.Errorf("CountTokens() = %v, want %v", count, 2)
+	}
+}
+
+func TestEncodeOrdinary(t *testing.T) {
+	tokens := EncodeOrdinary("gpt-3.5-turbo", "hello world")
+	if want := []int{15339, 1917}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("EncodeOrdinary() = %v, want %v", tokens, want)
+	}
+
+	tokens = EncodeOrdinary("gpt-3.5-turbo", "<|endoftext|>")
+	if len(tokens) <= 1 {
+		t.Errorf("EncodeOrdinary() = %v, want special token encoded as ordinary text", tokens)
+	}
+}
+
+func TestEncodeWithSpecialTokens(t *testing.T) {
+	tokens := EncodeWithSpecialTokens("gpt-3.5-turbo", "<|endoftext|>")
+	if want := []int{100257}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("EncodeWithSpecialTokens() = %v, want %v", tokens, want)
+	}
+
+	prompt := "hello world<|endoftext|>"
+	if count := CountTokens("gpt-3.5-turbo", prompt); len(EncodeWithSpecialTokens("gpt-3.5-turbo", prompt)) != count {
+		t.Errorf("len(EncodeWithSpecialTokens()) != CountTokens() = %v", count)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, prompt := range []string{"", "hello world", "<|endoftext|> 你好"} {
+		text, err := Decode("gpt-3.5-turbo", EncodeWithSpecialTokens("gpt-3.5-turbo", prompt))
+		if err != nil || text != prompt {
+			t.Errorf("Decode(EncodeWithSpecialTokens(%q)) = %q, %v", prompt, text, err)
+		}
+	}
+
+	tokens := EncodeOrdinary("gpt-3.5-turbo", "hello world hello world")
+	for _, position := range []int{0, 2, len(tokens) - 1} {
+		invalid := append([]int(nil), tokens...)
+		invalid[position] = 1 << 30
+		_, err := Decode("gpt-3.5-turbo", invalid)
+		var terr *InvalidTokenError
+		if !errors.As(err, &terr) || terr.Position != position || terr.Token != 1<<30 {
+			t.Errorf("Decode() error = %v, want invalid token at position %v", err, position)
+		}
+	}
+
+	if _, err := Decode("no-such-model", tokens); !errors.Is(err, ErrModelNotSupported) {
+		t.Errorf("Decode() error = %v, want ErrModelNotSupported", err)
+	}
+
+	// Some prefixes of a multi-token emoji sequence end inside a rune.
+	tokens = EncodeOrdinary("gpt-3.5-turbo", "🧑‍🚀🦩")
+	truncated := 0
+	for i := 0; i <= len(tokens); i++ {
+		text, err := Decode("gpt-3.5-turbo", tokens[:i])
+		switch {
+		case errors.Is(err, ErrInvalidUTF8):
+			truncated++
+		case err != nil || !utf8.ValidString(text):
+			t.Errorf("Decode(tokens[:%d]) = %q, %v", i, text, err)
+		}
+	}
+	if truncated == 0 {
+		t.Errorf("Decode() never returned ErrInvalidUTF8 for prefixes of %v", tokens)
+	}
+}
+
+func TestCanDecode(t *testing.T) {
+	var testcases = []struct {
+		Model  string
+		Tokens []int
+		Want   bool
+	}{
+		{"gpt-4", nil, true},
+		{"gpt-4", EncodeOrdinary("gpt-4", "hello world"), true},
+		// Beyond the 50k vocabulary of p50k_base, but within cl100k_base.
+		{"gpt-4", []int{100000}, true},
+		{"text-davinci-003", []int{100000}, false},
+		{"gpt-4", []int{-1}, false},
+		{"gpt-4", EncodeOrdinary("gpt-4", "🧑‍🚀")[:1], true},
+		{"no-such-model", []int{0}, false},
+	}
+
+	for _, tc := range testcases {
+		if got := CanDecode(tc.Model, tc.Tokens); got != tc.Want {
+			t.Errorf("CanDecode(%q, %v) = %v, want %v", tc.Model, tc.Tokens, got, tc.Want)
+		}
+	}
+}
+
+func TestGuessEncoding(t *testing.T) {
+	var testcases = []struct {
+		Tokens    []int
+		Encodings []string
+	}{
+		{[]int{15339, 50256}, []string{"r50k_base", "p50k_base", "p50k_edit", "cl100k_base"}},
+		// 50280 is the last ordinary token of p50k_base and 50282 is <|fim_middle|> in p50k_edit.
+		{[]int{50280}, []string{"p50k_base", "p50k_edit", "cl100k_base"}},
+		{[]int{50282}, []string{"p50k_edit", "cl100k_base"}},
+		{[]int{100000, 15339}, []string{"cl100k_base"}},
+		{[]int{1 << 30}, nil},
+	}
+
+	for _, tc := range testcases {
+		if got := GuessEncoding(tc.Tokens); !reflect.DeepEqual(got, tc.Encodings) {
+			t.Errorf("GuessEncoding(%v) = %v, want %v", tc.Tokens, got, tc.Encodings)
+		}
+	}
+}
+
+func TestDecodeTo(t *testing.T) {
+	var buf strings.Builder
+	n, err := DecodeTo(&buf, "gpt-3.5-turbo", EncodeOrdinary("gpt-3.5-turbo", "hello world"))
+	if err != nil || n != len("hello world") || buf.String() != "hello world" {
+		t.Errorf("DecodeTo() = %v, %v, wrote %q", n, err, buf.String())
+	}
+
+	buf.Reset()
+	if _, err := DecodeTo(&buf, "gpt-3.5-turbo", []int{1 << 30}); err == nil || buf.Len() != 0 {
+		t.Errorf("DecodeTo() error = %v, wrote %q, want error and nothing written", err, buf.String())
+	}
+}
+
+func TestDecodeRange(t *testing.T) {
+	hello := EncodeOrdinary("gpt-3.5-turbo", "hello")
+	tokens := append(hello, EncodeOrdinary("gpt-3.5-turbo", " world")...)
+	text, err := DecodeRange("gpt-3.5-turbo", tokens, len(hello), len(tokens))
+	if err != nil || text != " world" {
+		t.Errorf("DecodeRange() = %q, %v, want %q", text, err, " world")
+	}
+
+	var testcases = []struct {
+		Start, End int
+	}{
+		{-1, 1},
+		{1, 0},
+		{0, len(tokens) + 1},
+	}
+	for _, tc := range testcases {
+		if _, err := DecodeRange("gpt-3.5-turbo", tokens, tc.Start, tc.End); err == nil {
+			t.Errorf("DecodeRange(%d, %d) error = nil, want out of bounds", tc.Start, tc.End)
+		}
+	}
+}
+
+func TestCountTokensBinary(t *testing.T) {
+	if count := CountTokens("gpt-3.5-turbo", "hello\x00world"); count <= 2 {
+		t.Errorf("CountTokens() = %v, want input after NUL byte to be counted", count)
+	}
+	if count := CountTokens("gpt-3.5-turbo", "hello\xffworld"); count <= 2 {
+		t.Errorf("CountTokens() = %v, want invalid UTF-8 to be counted", count)
+	}
+}
+
+func FuzzCountTokens(f *testing.F) {
+	f.Add("hello world")
+	f.Add("<|endoftext|>")
+	f.Add("\x00\xff\xed\xa0\x80")
+	f.Add("  \n\n\t ")
+	f.Fuzz(
+		func(t *testing.T, prompt string) {
+			count := CountTokens("gpt-3.5-turbo", prompt)
+			if tokens := EncodeWithSpecialTokens("gpt-3.5-turbo", prompt); len(tokens) != count {
+				t.Errorf("len(EncodeWithSpecialTokens()) = %v, CountTokens() = %v", len(tokens), count)
+			}
+			if prompt != "" && count == 0 {
+				t.Errorf("CountTokens(%q) = 0, want at least one token", prompt)
+			}
+		},
+	)
+}
+
+// Invalid UTF-8 is replaced with U+FFFD before encoding, so only valid prompts can round trip.
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("hello world")
+	f.Add("<|endoftext|> 你好 🧑‍🚀")
+	f.Add("\x00\xff\xed\xa0\x80")
+	f.Add("  \n\n\t ")
+	f.Fuzz(
+		func(t *testing.T, prompt string) {
+			if !utf8.ValidString(prompt) {
+				return
+			}
+			text, err := Decode("gpt-3.5-turbo", EncodeWithSpecialTokens("gpt-3.5-turbo", prompt))
+			if err != nil || text != prompt {
+				t.Errorf("Decode(EncodeWithSpecialTokens(%q)) = %q, %v", prompt, text, err)
+			}
+		},
+	)
+}
+
 func TestGetContextSize(t *testing.T) {
-	count := GetContextSize("gpt-3.5-turbo")
-	if count != 4096 {
-		t.Errorf("GetContextSize() = %v, want %v", count, 4096)
+	var testcases = []struct {
+		Model string
+		Size  int
+	}{
+		{"gpt-3.5-turbo", 4096},
+		{"gpt-3.5-turbo-instruct", 4096},
+		{"gpt-3.5-turbo-instruct-0914", 4096},
+		{"gpt-3.5-turbo-16k", 16384},
+		{"gpt-35-turbo", 4096},
+		{"gpt-35-turbo-0301", 4096},
+		{"gpt-35-turbo-16k", 16384},
+		{"gpt-4", 8192},
+		{"gpt-4-0314", 8192},
+		{"gpt-4-32k", 32768},
+		{"gpt-4-32k-0314", 32768},
+		{"text-davinci-003", 4097},
+		{"code-davinci-002", 8001},
+		{"davinci", 2049},
+		{"text-embedding-ada-002", 8191},
+		{"text-embedding-3-small", 8191},
+		{"text-embedding-3-large", 8191},
+		{"text-moderation-latest", 32768},
+		{"GPT-4-32K", 32768},
+		{"Gpt-3.5-Turbo-16k", 16384},
+		{"no-such-model", 4096},
+	}
+
+	for _, tc := range testcases {
+		if size := GetContextSize(tc.Model); size != tc.Size {
+			t.Errorf("GetContextSize(%q) = %v, want %v", tc.Model, size, tc.Size)
+		}
+	}
+}
+
+func TestMaxContextTokens(t *testing.T) {
+	var testcases = []struct {
+		Model string
+		Size  int
+	}{
+		{"gpt-4o", 128000},
+		{"gpt-4o-2024-08-06", 128000},
+		{"gpt-4-turbo", 128000},
+		{"gpt-4-1106-preview", 128000},
+		{"gpt-4", 8192},
+		{"GPT-4", 8192},
+		{"gpt-4-0613", 8192},
+		{"gpt-4-32k", 32768},
+		{"gpt-4.1", 1047576},
+		{"gpt-4.1-mini-2025-04-14", 1047576},
+		{"gpt-4.5-preview", 128000},
+		{"gpt-3.5-turbo", 16385},
+		{"gpt-3.5-turbo-0613", 4096},
+		{"o1-mini-2024-09-12", 128000},
+		{"o1", 200000},
+		{"o3-mini", 200000},
+		{"davinci-002", 16384},
+		{"davinci", 2049},
+	}
+
+	for _, tc := range testcases {
+		size, err := MaxContextTokens(tc.Model)
+		if err != nil {
+			t.Errorf("MaxContextTokens(%q) error = %v", tc.Model, err)
+		} else if size != tc.Size {
+			t.Errorf("MaxContextTokens(%q) = %v, want %v", tc.Model, size, tc.Size)
+		}
+	}
+
+	for _, model := range []string{"no-such-model", "gpt-4x", "GPT-4.7"} {
+		if _, err := MaxContextTokens(model); err == nil || !strings.Contains(err.Error(), model) {
+			t.Errorf("MaxContextTokens(%q) error = %v, want error naming the model", model, err)
+		}
+	}
+}
+
+func TestRemainingTokens(t *testing.T) {
+	remaining, err := RemainingTokens("gpt-4", "hello world", 1000)
+	if err != nil {
+		t.Fatalf("RemainingTokens() error = %v", err)
+	}
+	if want := 8192 - 2 - 1000; remaining != want {
+		t.Errorf("RemainingTokens() = %v, want %v", remaining, want)
+	}
+
+	remaining, err = RemainingTokens("gpt-4", "hello world", 8192)
+	if err != nil {
+		t.Fatalf("RemainingTokens() error = %v", err)
+	}
+	if remaining != -2 {
+		t.Errorf("RemainingTokens() = %v, want %v", remaining, -2)
+	}
+
+	if _, err := RemainingTokens("gpt-4", strings.Repeat("hello ", 9000), 0); err == nil {
+		t.Errorf("RemainingTokens() error = nil, want error for prompt exceeding the context")
+	}
+	if _, err := RemainingTokens("no-such-model", "hello world", 0); !errors.Is(err, ErrModelNotSupported) {
+		t.Errorf("RemainingTokens() error = %v, want ErrModelNotSupported", err)
 	}
 }
 
@@ -32,6 +408,32 @@ func BenchmarkCountTokens(b *testing.B) {
 	}
 }
 
+// Short inputs measure the per-call cost of crossing into Rust. Results: every case reports
+// 0 B/op and 0 allocs/op and makes a single cgo call, where passing the model as a C string
+// took three (C.CString, count_tokens and C.free). Timings depend on the tiktoken-rs build
+// linked in, so compare ns/op on one machine before and after a change.
+func BenchmarkCountTokensShort(b *testing.B) {
+	var inputs = []struct {
+		Name   string
+		Prompt string
+	}{
+		{"word", "hello"},
+		{"sentence", "The quick brown fox jumps over the lazy dog."},
+		{"paragraph", strings.Repeat("The quick brown fox jumps over the lazy dog. ", 10)},
+	}
+
+	for _, input := range inputs {
+		b.Run(
+			input.Name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					CountTokens("gpt-3.5-turbo", input.Prompt)
+				}
+			},
+		)
+	}
+}
+
 func TestCountMessagesTokens(t *testing.T) {
 	if os.Getenv("OPENAI_API_KEY") == "" {
 		t.Skip("OPENAI_API_KEY is not set")
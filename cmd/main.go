@@ -1,15 +1,96 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	tiktoken_go "github.com/j178/tiktoken-go"
 )
 
 func main() {
-	in, _ := io.ReadAll(os.Stdin)
-	count := tiktoken_go.CountTokens("gpt-3.5-turbo", string(in))
+	model := flag.String("model", "gpt-3.5-turbo", "model whose tokenizer is used, overrides $TIKTOKEN_MODEL")
+	compare := flag.String("compare", "", "comma-separated `models` to print token counts for")
+	file := flag.String("file", "", "read input from `path` instead of stdin")
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		usageError("unexpected arguments: %s", strings.Join(flag.Args(), " "))
+	}
+	if *compare != "" && isFlagSet("model") {
+		usageError("-model and -compare cannot be used together")
+	}
+	*model = resolveModel(*model, isFlagSet("model"), os.Getenv)
+	if *compare == "" && !tiktoken_go.IsModelSupported(*model) {
+		usageError("unsupported model: %s", *model)
+	}
+
+	in, err := readInput(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *compare != "" {
+		printComparison(strings.Split(*compare, ","), string(in))
+		return
+	}
+	count := tiktoken_go.CountTokens(*model, string(in))
 	fmt.Println(count)
 }
+
+// usageError reports an invalid flag combination and exits with status 2, like the flag package does.
+func usageError(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// resolveModel returns the model to use: the -model flag if it was given,
+// then the TIKTOKEN_MODEL environment variable, then the flag's default.
+func resolveModel(model string, explicit bool, getenv func(string) string) string {
+	if explicit {
+		return model
+	}
+	if env := getenv("TIKTOKEN_MODEL"); env != "" {
+		return env
+	}
+	return model
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(
+		func(f *flag.Flag) {
+			if f.Name == name {
+				set = true
+			}
+		},
+	)
+	return set
+}
+
+func readInput(file string) ([]byte, error) {
+	if file == "" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// printComparison prints a table of token counts, one row per model.
+// Unknown models get an error row instead of stopping the comparison.
+func printComparison(models []string, prompt string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tTOKENS")
+	for _, model := range models {
+		model = strings.TrimSpace(model)
+		if !tiktoken_go.IsModelSupported(model) {
+			fmt.Fprintf(w, "%s\terror: unsupported model\n", model)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\n", model, tiktoken_go.CountTokens(model, prompt))
+	}
+	w.Flush()
+}
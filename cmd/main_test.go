@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestResolveModel(t *testing.T) {
+	var testcases = []struct {
+		Model    string
+		Explicit bool
+		Env      string
+		Want     string
+	}{
+		{"gpt-3.5-turbo", false, "", "gpt-3.5-turbo"},
+		{"gpt-3.5-turbo", false, "gpt-4", "gpt-4"},
+		{"text-davinci-003", true, "gpt-4", "text-davinci-003"},
+	}
+
+	for _, tc := range testcases {
+		getenv := func(key string) string {
+			if key == "TIKTOKEN_MODEL" {
+				return tc.Env
+			}
+			return ""
+		}
+		if got := resolveModel(tc.Model, tc.Explicit, getenv); got != tc.Want {
+			t.Errorf("resolveModel(%q, %v) with TIKTOKEN_MODEL=%q = %v, want %v", tc.Model, tc.Explicit, tc.Env, got, tc.Want)
+		}
+	}
+}
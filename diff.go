@@ -0,0 +1,175 @@
+//go:build !windows
+
+package tiktoken_go
+
+// DiffOp is the kind of a DiffSpan.
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffRemove
+	DiffAdd
+)
+
+// DiffSpan is a run of tokens that is unchanged, removed from the first prompt, or added in the second.
+type DiffSpan struct {
+	Op     DiffOp
+	Tokens []int
+}
+
+// DiffResult is a token-level diff between two prompts.
+type DiffResult struct {
+	// Delta is the token count of the second prompt minus that of the first.
+	Delta   int
+	Added   int
+	Removed int
+	// Spans is the edit script turning the tokens of the first prompt into those of the second.
+	Spans []DiffSpan
+}
+
+// Diff tokenizes both prompts and compares the token ids.
+// It trims the common prefix and suffix, then finds a longest common subsequence of the rest with
+// Hirschberg's algorithm, which takes O(n*m) time in the remaining token counts but only O(n+m) memory.
+func Diff(model, a, b string) DiffResult {
+	return diffTokens(EncodeWithSpecialTokens(model, a), EncodeWithSpecialTokens(model, b))
+}
+
+func diffTokens(a, b []int) DiffResult {
+	d := differ{result: DiffResult{Delta: len(b) - len(a)}}
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	d.emit(DiffEqual, a[:prefix])
+	d.diff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	d.emit(DiffEqual, a[len(a)-suffix:])
+	return d.result
+}
+
+type differ struct {
+	result DiffResult
+}
+
+// emit appends tokens to the edit script, merging them into the last span if it has the same op.
+func (d *differ) emit(op DiffOp, tokens []int) {
+	if len(tokens) == 0 {
+		return
+	}
+	switch op {
+	case DiffRemove:
+		d.result.Removed += len(tokens)
+	case DiffAdd:
+		d.result.Added += len(tokens)
+	}
+	if n := len(d.result.Spans); n > 0 && d.result.Spans[n-1].Op == op {
+		d.result.Spans[n-1].Tokens = append(d.result.Spans[n-1].Tokens, tokens...)
+		return
+	}
+	d.result.Spans = append(d.result.Spans, DiffSpan{Op: op, Tokens: append([]int(nil), tokens...)})
+}
+
+// diff emits an edit script for a and b, splitting a in half and b where the
+// longest common subsequences of the two halves add up to the longest overall.
+func (d *differ) diff(a, b []int) {
+	switch {
+	case len(a) == 0:
+		d.emit(DiffAdd, b)
+	case len(b) == 0:
+		d.emit(DiffRemove, a)
+	case len(a) == 1:
+		for j, token := range b {
+			if token == a[0] {
+				d.emit(DiffAdd, b[:j])
+				d.emit(DiffEqual, a)
+				d.emit(DiffAdd, b[j+1:])
+				return
+			}
+		}
+		d.emit(DiffRemove, a)
+		d.emit(DiffAdd, b)
+	default:
+		mid := len(a) / 2
+		forward := lcsPrefixLengths(a[:mid], b)
+		backward := lcsSuffixLengths(a[mid:], b)
+		split := 0
+		for j := range forward {
+			if forward[j]+backward[j] > forward[split]+backward[split] {
+				split = j
+			}
+		}
+		d.diff(a[:mid], b[:split])
+		d.diff(a[mid:], b[split:])
+	}
+}
+
+// lcsPrefixLengths returns, for each j, the length of the longest common subsequence of a and b[:j].
+func lcsPrefixLengths(a, b []int) []int {
+	row := make([]int, len(b)+1)
+	for _, x := range a {
+		diag := 0
+		for j, y := range b {
+			up := row[j+1]
+			if x == y {
+				row[j+1] = diag + 1
+			} else if row[j] > row[j+1] {
+				row[j+1] = row[j]
+			}
+			diag = up
+		}
+	}
+	return row
+}
+
+// lcsSuffixLengths returns, for each j, the length of the longest common subsequence of a and b[j:].
+func lcsSuffixLengths(a, b []int) []int {
+	row := make([]int, len(b)+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		diag := 0
+		for j := len(b) - 1; j >= 0; j-- {
+			down := row[j]
+			if a[i] == b[j] {
+				row[j] = diag + 1
+			} else if row[j+1] > row[j] {
+				row[j] = row[j+1]
+			}
+			diag = down
+		}
+	}
+	return row
+}
+
+// TokenDistance returns the edit distance between the token ids of two prompts,
+// counting insertions, deletions and substitutions of single tokens.
+// It takes O(n*m) time in the token counts, but only O(m) memory.
+func TokenDistance(model, a, b string) int {
+	return tokenDistance(EncodeWithSpecialTokens(model, a), EncodeWithSpecialTokens(model, b))
+}
+
+func tokenDistance(a, b []int) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			curr[j] = prev[j-1]
+			if a[i-1] != b[j-1] {
+				curr[j]++
+			}
+			if d := prev[j] + 1; d < curr[j] {
+				curr[j] = d
+			}
+			if d := curr[j-1] + 1; d < curr[j] {
+				curr[j] = d
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,124 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDiffTokens(t *testing.T) {
+	var testcases = []struct {
+		A, B []int
+		Want DiffResult
+	}{
+		{
+			nil, nil,
+			DiffResult{},
+		},
+		{
+			[]int{1, 2, 3}, []int{1, 2, 3},
+			DiffResult{Spans: []DiffSpan{{DiffEqual, []int{1, 2, 3}}}},
+		},
+		{
+			[]int{1, 2, 3}, []int{1, 4, 5, 3},
+			DiffResult{
+				Delta: 1, Added: 2, Removed: 1,
+				Spans: []DiffSpan{{DiffEqual, []int{1}}, {DiffRemove, []int{2}}, {DiffAdd, []int{4, 5}}, {DiffEqual, []int{3}}},
+			},
+		},
+		{
+			[]int{1, 2}, nil,
+			DiffResult{Delta: -2, Removed: 2, Spans: []DiffSpan{{DiffRemove, []int{1, 2}}}},
+		},
+		{
+			nil, []int{7},
+			DiffResult{Delta: 1, Added: 1, Spans: []DiffSpan{{DiffAdd, []int{7}}}},
+		},
+	}
+
+	for _, tc := range testcases {
+		if got := diffTokens(tc.A, tc.B); !reflect.DeepEqual(got, tc.Want) {
+			t.Errorf("diffTokens(%v, %v) = %+v, want %+v", tc.A, tc.B, got, tc.Want)
+		}
+	}
+
+	// A long shared prefix is trimmed rather than filling an n*m table.
+	prefix := make([]int, 100000)
+	for i := range prefix {
+		prefix[i] = i
+	}
+	got := diffTokens(append(prefix[:len(prefix):len(prefix)], -1), append(prefix[:len(prefix):len(prefix)], -2))
+	want := DiffResult{
+		Added: 1, Removed: 1,
+		Spans: []DiffSpan{{DiffEqual, prefix}, {DiffRemove, []int{-1}}, {DiffAdd, []int{-2}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffTokens() with a long shared prefix = %d spans, %d added, %d removed, want one of each after the prefix",
+			len(got.Spans), got.Added, got.Removed)
+	}
+}
+
+func TestDiffTokensMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []int {
+		tokens := make([]int, rng.Intn(12))
+		for i := range tokens {
+			tokens[i] = rng.Intn(4)
+		}
+		return tokens
+	}
+	for n := 0; n < 1000; n++ {
+		a, b := random(), random()
+		result := diffTokens(a, b)
+
+		gotA, gotB := []int{}, []int{}
+		for _, span := range result.Spans {
+			if span.Op != DiffAdd {
+				gotA = append(gotA, span.Tokens...)
+			}
+			if span.Op != DiffRemove {
+				gotB = append(gotB, span.Tokens...)
+			}
+		}
+		if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+			t.Fatalf("diffTokens(%v, %v) = %+v, which does not turn a into b", a, b, result.Spans)
+		}
+		if edits := len(a) + len(b) - 2*lcsSuffixLengths(a, b)[0]; result.Added+result.Removed != edits {
+			t.Fatalf("diffTokens(%v, %v) makes %d edits, want %d", a, b, result.Added+result.Removed, edits)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	result := Diff("gpt-3.5-turbo", "hello world", "hello there world")
+	if result.Delta != 1 || result.Added != 1 || result.Removed != 0 {
+		t.Errorf("Diff() = %+v, want one added token", result)
+	}
+}
+
+func TestTokenDistance(t *testing.T) {
+	var testcases = []struct {
+		A, B     []int
+		Distance int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, nil, 3},
+		{nil, []int{4, 5}, 2},
+		{[]int{1, 2, 3}, []int{1, 4, 3}, 1},
+		{[]int{1, 2, 3, 4}, []int{2, 3, 4, 5}, 2},
+		{[]int{1, 2}, []int{2, 1}, 2},
+	}
+
+	for _, tc := range testcases {
+		if got := tokenDistance(tc.A, tc.B); got != tc.Distance {
+			t.Errorf("tokenDistance(%v, %v) = %v, want %v", tc.A, tc.B, got, tc.Distance)
+		}
+	}
+
+	if d := TokenDistance("gpt-3.5-turbo", "hello world", "hello there world"); d != 1 {
+		t.Errorf("TokenDistance() = %v, want %v", d, 1)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CountImageTokens returns the number of prompt tokens an image input costs for a vision model,
+// following https://platform.openai.com/docs/guides/vision.
+// With "low" detail an image costs a flat base amount. With "high" detail the image is scaled to fit
+// within 2048x2048, then down so that its shortest side is at most 768px, and every 512px tile
+// adds to the base cost. "auto" and "" are counted as "high", which is the maximum the API charges.
+func CountImageTokens(model string, width, height int, detail string) (int, error) {
+	if width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+
+	name := strings.ToLower(model)
+	var baseTokens, tileTokens int
+	switch {
+	case strings.HasPrefix(name, "gpt-4o-mini"):
+		baseTokens, tileTokens = 2833, 5667
+	// gpt-4-turbo-preview predates vision support in gpt-4-turbo and is text-only.
+	case strings.HasPrefix(name, "gpt-4o"), strings.Contains(name, "vision"),
+		strings.HasPrefix(name, "gpt-4-turbo") && !strings.HasPrefix(name, "gpt-4-turbo-preview"):
+		baseTokens, tileTokens = 85, 170
+	default:
+		return 0, fmt.Errorf("model %s does not support image inputs", model)
+	}
+
+	switch detail {
+	case "low":
+		return baseTokens, nil
+	case "high", "auto", "":
+	default:
+		return 0, fmt.Errorf("invalid image detail %q", detail)
+	}
+
+	w, h := float64(width), float64(height)
+	if longest := math.Max(w, h); longest > 2048 {
+		w, h = w*2048/longest, h*2048/longest
+	}
+	if shortest := math.Min(w, h); shortest > 768 {
+		w, h = w*768/shortest, h*768/shortest
+	}
+	tiles := int(math.Ceil(w/512) * math.Ceil(h/512))
+	return baseTokens + tiles*tileTokens, nil
+}
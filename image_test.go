@@ -0,0 +1,49 @@
+//go:build !windows
+
+package tiktoken_go
+
+import "testing"
+
+func TestCountImageTokens(t *testing.T) {
+	// Examples from https://platform.openai.com/docs/guides/vision
+	var testcases = []struct {
+		Model  string
+		Width  int
+		Height int
+		Detail string
+		Count  int
+	}{
+		{"gpt-4o", 1024, 1024, "high", 765},
+		{"gpt-4o", 2048, 4096, "high", 1105},
+		{"gpt-4o", 4096, 8192, "low", 85},
+		{"gpt-4-turbo", 1024, 1024, "auto", 765},
+		{"gpt-4-turbo-2024-04-09", 1024, 1024, "high", 765},
+		{"GPT-4o", 1024, 1024, "high", 765},
+		{"gpt-4-vision-preview", 512, 512, "high", 255},
+		{"gpt-4o-mini", 1024, 1024, "high", 25501},
+		{"gpt-4o-mini", 1024, 1024, "low", 2833},
+	}
+
+	for _, tc := range testcases {
+		count, err := CountImageTokens(tc.Model, tc.Width, tc.Height, tc.Detail)
+		if err != nil {
+			t.Errorf("CountImageTokens(%q, %d, %d, %q) error = %v", tc.Model, tc.Width, tc.Height, tc.Detail, err)
+			continue
+		}
+		if count != tc.Count {
+			t.Errorf("CountImageTokens(%q, %d, %d, %q) = %v, want %v", tc.Model, tc.Width, tc.Height, tc.Detail, count, tc.Count)
+		}
+	}
+
+	for _, model := range []string{"gpt-3.5-turbo", "gpt-4-turbo-preview"} {
+		if _, err := CountImageTokens(model, 512, 512, "high"); err == nil {
+			t.Errorf("CountImageTokens(%q) error = nil, want error for model without vision", model)
+		}
+	}
+	if _, err := CountImageTokens("gpt-4o", 512, 512, "medium"); err == nil {
+		t.Errorf("CountImageTokens() error = nil, want error for invalid detail")
+	}
+	if _, err := CountImageTokens("gpt-4o", 0, 512, "high"); err == nil {
+		t.Errorf("CountImageTokens() error = nil, want error for invalid size")
+	}
+}
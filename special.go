@@ -0,0 +1,52 @@
+//go:build !windows
+
+package tiktoken_go
+
+import "fmt"
+
+// SpecialTokenID returns the id of a special token such as "<|endoftext|>" in the model's encoding.
+// The library does not expose its special token table, so a literal counts as special when it
+// encodes to a single id with special tokens allowed but to several ids as ordinary text.
+// It reports false for other literals and for an unsupported model.
+func SpecialTokenID(model, literal string) (int, bool) {
+	if !IsModelSupported(model) {
+		return 0, false
+	}
+	tokens := EncodeWithSpecialTokens(model, literal)
+	if len(tokens) != 1 || len(EncodeOrdinary(model, literal)) < 2 {
+		return 0, false
+	}
+	return tokens[0], true
+}
+
+// SpecialTokenName returns the literal of the special token with the given id, the inverse of SpecialTokenID.
+// It reports false if id is an ordinary token, is not part of the encoding, or the model is not supported.
+func SpecialTokenName(model string, id int) (string, bool) {
+	literal, err := Decode(model, []int{id})
+	if err != nil {
+		return "", false
+	}
+	if special, ok := SpecialTokenID(model, literal); !ok || special != id {
+		return "", false
+	}
+	return literal, true
+}
+
+// EncodeWithBookends returns the id of the special token bos, the ordinary tokens of input, and the id of
+// the special token eos, e.g. for building training sequences delimited by "<|endoftext|>".
+// An error is returned if bos or eos is not a special token of the model's encoding.
+func EncodeWithBookends(model, input, bos, eos string) ([]int, error) {
+	if !IsModelSupported(model) {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotSupported, model)
+	}
+	bosID, ok := SpecialTokenID(model, bos)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a special token of model %s", bos, model)
+	}
+	eosID, ok := SpecialTokenID(model, eos)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a special token of model %s", eos, model)
+	}
+	tokens := append([]int{bosID}, EncodeOrdinary(model, input)...)
+	return append(tokens, eosID), nil
+}
@@ -0,0 +1,72 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSpecialTokenID(t *testing.T) {
+	var testcases = []struct {
+		Model   string
+		Literal string
+		ID      int
+		OK      bool
+	}{
+		{"gpt-4", "<|endoftext|>", 100257, true},
+		{"gpt-4", "<|fim_prefix|>", 100258, true},
+		{"text-davinci-003", "<|endoftext|>", 50256, true},
+		{"text-davinci-edit-001", "<|endoftext|>", 50256, true},
+		{"davinci", "<|endoftext|>", 50256, true},
+		{"davinci", "<|fim_prefix|>", 0, false},
+		{"gpt-4", "hello", 0, false},
+		{"gpt-4", "", 0, false},
+		{"no-such-model", "<|endoftext|>", 0, false},
+	}
+
+	for _, tc := range testcases {
+		id, ok := SpecialTokenID(tc.Model, tc.Literal)
+		if id != tc.ID || ok != tc.OK {
+			t.Errorf("SpecialTokenID(%q, %q) = %v, %v, want %v, %v", tc.Model, tc.Literal, id, ok, tc.ID, tc.OK)
+		}
+	}
+}
+
+func TestSpecialTokenName(t *testing.T) {
+	var testcases = []struct {
+		Model   string
+		ID      int
+		Literal string
+		OK      bool
+	}{
+		{"gpt-4", 100257, "<|endoftext|>", true},
+		{"text-davinci-003", 50256, "<|endoftext|>", true},
+		{"gpt-4", EncodeOrdinary("gpt-4", "hello")[0], "", false},
+		{"gpt-4", -1, "", false},
+		{"no-such-model", 0, "", false},
+	}
+
+	for _, tc := range testcases {
+		literal, ok := SpecialTokenName(tc.Model, tc.ID)
+		if literal != tc.Literal || ok != tc.OK {
+			t.Errorf("SpecialTokenName(%q, %v) = %q, %v, want %q, %v", tc.Model, tc.ID, literal, ok, tc.Literal, tc.OK)
+		}
+	}
+}
+
+func TestEncodeWithBookends(t *testing.T) {
+	tokens, err := EncodeWithBookends("gpt-4", "hello <|endoftext|>", "<|endoftext|>", "<|endoftext|>")
+	want := append(append([]int{100257}, EncodeOrdinary("gpt-4", "hello <|endoftext|>")...), 100257)
+	if err != nil || !reflect.DeepEqual(tokens, want) {
+		t.Errorf("EncodeWithBookends() = %v, %v, want %v", tokens, err, want)
+	}
+
+	if _, err := EncodeWithBookends("gpt-4", "hello", "<|endoftext|>", "</s>"); err == nil {
+		t.Errorf("EncodeWithBookends() error = nil, want error for unknown special token")
+	}
+	if _, err := EncodeWithBookends("no-such-model", "hello", "<|endoftext|>", "<|endoftext|>"); !errors.Is(err, ErrModelNotSupported) {
+		t.Errorf("EncodeWithBookends() error = %v, want ErrModelNotSupported", err)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package tiktoken_go
+
+import "sort"
+
+// TokenStats summarizes the tokenization of a prompt.
+type TokenStats struct {
+	Tokens        int
+	UniqueTokens  int
+	BytesPerToken float64
+	// MostFrequent holds up to 10 of the most frequent tokens, most frequent first.
+	MostFrequent []TokenCount
+}
+
+// TokenCount is the number of occurrences of a token id.
+type TokenCount struct {
+	ID    int
+	Count int
+}
+
+// Stats tokenizes prompt and returns statistics about the tokens, useful for spotting inputs that tokenize poorly.
+func Stats(model, prompt string) TokenStats {
+	tokens := EncodeWithSpecialTokens(model, prompt)
+	counts := make(map[int]int)
+	for _, id := range tokens {
+		counts[id]++
+	}
+
+	stats := TokenStats{
+		Tokens:       len(tokens),
+		UniqueTokens: len(counts),
+		MostFrequent: make([]TokenCount, 0, len(counts)),
+	}
+	if len(tokens) > 0 {
+		stats.BytesPerToken = float64(len(prompt)) / float64(len(tokens))
+	}
+	for id, count := range counts {
+		stats.MostFrequent = append(stats.MostFrequent, TokenCount{ID: id, Count: count})
+	}
+	sort.Slice(
+		stats.MostFrequent, func(i, j int) bool {
+			a, b := stats.MostFrequent[i], stats.MostFrequent[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.ID < b.ID
+		},
+	)
+	if len(stats.MostFrequent) > 10 {
+		stats.MostFrequent = stats.MostFrequent[:10]
+	}
+	return stats
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	stats := Stats("gpt-3.5-turbo", "hello world hello world hello")
+	want := TokenStats{
+		Tokens:        5,
+		UniqueTokens:  3,
+		BytesPerToken: 29.0 / 5,
+		MostFrequent:  []TokenCount{{ID: 1917, Count: 2}, {ID: 24748, Count: 2}, {ID: 15339, Count: 1}},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+
+	if stats := Stats("gpt-3.5-turbo", ""); stats.Tokens != 0 || stats.BytesPerToken != 0 {
+		t.Errorf("Stats() = %+v, want empty stats", stats)
+	}
+}
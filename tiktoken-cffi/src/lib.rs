@@ -1,5 +1,7 @@
-use std::ffi::CStr;
-use std::sync::Arc;
+use std::borrow::Cow;
+use std::cell::Cell;
+use std::panic::{self, AssertUnwindSafe};
+use std::sync::{Arc, Once};
 
 use parking_lot::Mutex;
 use tiktoken_rs::CoreBPE;
@@ -21,26 +23,225 @@ pub fn get_bpe_from_tokenizer(tokenizer: Tokenizer) -> Result<Arc<Mutex<CoreBPE>
     Ok(bpe)
 }
 
+// Models that the tokenizer tables of the pinned tiktoken-rs do not know about yet.
+const MODEL_TO_TOKENIZER: &[(&str, Tokenizer)] = &[
+    ("text-embedding-ada-002-v2", Tokenizer::Cl100kBase),
+    ("text-embedding-3-small", Tokenizer::Cl100kBase),
+    ("text-embedding-3-large", Tokenizer::Cl100kBase),
+    // Azure deployment names drop the dot from the version.
+    ("gpt-35-turbo", Tokenizer::Cl100kBase),
+];
+
+// Prefixes are tried in slice order and the first match wins, so when two prefixes
+// can match the same name the longer one must be listed first. tiktoken-rs keeps
+// its own prefix table the same way, as an ordered slice rather than a map.
+const MODEL_PREFIX_TO_TOKENIZER: &[(&str, Tokenizer)] = &[
+    ("gpt-35-turbo-", Tokenizer::Cl100kBase),
+    // text-moderation-latest, text-moderation-stable, text-moderation-007, ...
+    ("text-moderation-", Tokenizer::Cl100kBase),
+];
+
+// OpenAI model names are lowercase, so names are matched case-insensitively.
+pub fn get_tokenizer_for_model(model: &str) -> Option<Tokenizer> {
+    let model = if model.bytes().any(|b| b.is_ascii_uppercase()) {
+        Cow::Owned(model.to_lowercase())
+    } else {
+        Cow::Borrowed(model)
+    };
+    let model = model.as_ref();
+    MODEL_TO_TOKENIZER
+        .iter()
+        .find(|(name, _)| *name == model)
+        .or_else(|| {
+            MODEL_PREFIX_TO_TOKENIZER
+                .iter()
+                .find(|(prefix, _)| model.starts_with(prefix))
+        })
+        .map(|(_, tokenizer)| *tokenizer)
+        .or_else(|| get_tokenizer(model))
+}
+
 pub fn get_bpe_from_model(model: &str) -> Result<Arc<Mutex<CoreBPE>>> {
-    let tokenizer =
-        get_tokenizer(model).ok_or_else(|| anyhow!("No tokenizer found for model {}", model))?;
+    let tokenizer = get_tokenizer_for_model(model)
+        .ok_or_else(|| anyhow!("No tokenizer found for model {}", model))?;
     let bpe = get_bpe_from_tokenizer(tokenizer)?;
     Ok(bpe)
 }
 
+// Strings are passed as a pointer and length rather than a C string, so that the
+// Go side can pass its own memory without copying and NUL bytes are kept.
+// Invalid UTF-8 is replaced with U+FFFD instead of panicking.
+fn str_from_raw<'a>(s: *const libc::c_char, len: libc::size_t) -> Cow<'a, str> {
+    if len == 0 {
+        return Cow::Borrowed("");
+    }
+    let bytes = unsafe { std::slice::from_raw_parts(s as *const u8, len) };
+    String::from_utf8_lossy(bytes)
+}
+
 #[no_mangle]
-pub extern "C" fn count_tokens(model: *const libc::c_char, prompt: *const libc::c_char) -> libc::c_uint {
-    let model = unsafe { CStr::from_ptr(model).to_str().unwrap() };
-    let prompt = unsafe { CStr::from_ptr(prompt).to_str().unwrap() };
-    let bpe = get_bpe_from_model(model).unwrap();
-    let count = bpe.lock().encode_with_special_tokens(prompt).len();
+pub extern "C" fn is_model_supported(model: *const libc::c_char, model_len: libc::size_t) -> bool {
+    let model = str_from_raw(model, model_len);
+    get_tokenizer_for_model(&model).is_some()
+}
+
+// Returns a static NUL-terminated encoding name, or NULL if the model is unknown.
+#[no_mangle]
+pub extern "C" fn get_encoding_name(
+    model: *const libc::c_char,
+    model_len: libc::size_t,
+) -> *const libc::c_char {
+    let model = str_from_raw(model, model_len);
+    let name: &'static [u8] = match get_tokenizer_for_model(&model) {
+        Some(Tokenizer::Cl100kBase) => b"cl100k_base\0",
+        Some(Tokenizer::R50kBase) => b"r50k_base\0",
+        Some(Tokenizer::P50kBase) => b"p50k_base\0",
+        Some(Tokenizer::P50kEdit) => b"p50k_edit\0",
+        Some(Tokenizer::Gpt2) => b"gpt2\0",
+        None => return std::ptr::null(),
+    };
+    name.as_ptr() as *const libc::c_char
+}
+
+#[no_mangle]
+pub extern "C" fn count_tokens(
+    model: *const libc::c_char,
+    model_len: libc::size_t,
+    prompt: *const libc::c_char,
+    prompt_len: libc::size_t,
+) -> libc::c_uint {
+    let model = str_from_raw(model, model_len);
+    let prompt = str_from_raw(prompt, prompt_len);
+    let bpe = get_bpe_from_model(&model).unwrap();
+    let count = bpe.lock().encode_with_special_tokens(&prompt).len();
     count as libc::c_uint
 }
 
+fn encode(
+    model: *const libc::c_char,
+    model_len: libc::size_t,
+    prompt: *const libc::c_char,
+    prompt_len: libc::size_t,
+    len: *mut libc::size_t,
+    encoder: impl Fn(&CoreBPE, &str) -> Vec<usize>,
+) -> *mut libc::c_uint {
+    let model = str_from_raw(model, model_len);
+    let prompt = str_from_raw(prompt, prompt_len);
+    let bpe = get_bpe_from_model(&model).unwrap();
+    let tokens: Box<[libc::c_uint]> = encoder(&bpe.lock(), &prompt)
+        .into_iter()
+        .map(|token| token as libc::c_uint)
+        .collect();
+    unsafe { *len = tokens.len() };
+    Box::into_raw(tokens) as *mut libc::c_uint
+}
+
+#[no_mangle]
+pub extern "C" fn encode_ordinary(
+    model: *const libc::c_char,
+    model_len: libc::size_t,
+    prompt: *const libc::c_char,
+    prompt_len: libc::size_t,
+    len: *mut libc::size_t,
+) -> *mut libc::c_uint {
+    encode(model, model_len, prompt, prompt_len, len, |bpe, prompt| bpe.encode_ordinary(prompt))
+}
+
+#[no_mangle]
+pub extern "C" fn encode_with_special_tokens(
+    model: *const libc::c_char,
+    model_len: libc::size_t,
+    prompt: *const libc::c_char,
+    prompt_len: libc::size_t,
+    len: *mut libc::size_t,
+) -> *mut libc::c_uint {
+    encode(model, model_len, prompt, prompt_len, len, |bpe, prompt| bpe.encode_with_special_tokens(prompt))
+}
+
+// Releases a token buffer returned by one of the encode functions.
+#[no_mangle]
+pub extern "C" fn free_tokens(tokens: *mut libc::c_uint, len: libc::size_t) {
+    unsafe { drop(Box::from_raw(std::ptr::slice_from_raw_parts_mut(tokens, len))) };
+}
+
+// Status codes returned by decode.
+const DECODE_OK: libc::c_int = 0;
+const DECODE_INVALID_TOKEN: libc::c_int = 1;
+const DECODE_INVALID_UTF8: libc::c_int = 2;
+const DECODE_FAILED: libc::c_int = 3;
+
+thread_local! {
+    static SILENCE_PANICS: Cell<bool> = Cell::new(false);
+}
+
+// Runs f, catching a panic without the default hook printing it to the host's
+// stderr. The hook is wrapped once and only stays quiet on the calling thread
+// while f runs, so panics elsewhere are still reported as before.
+fn catch_silently<R>(f: impl FnOnce() -> R) -> std::thread::Result<R> {
+    static INSTALL_HOOK: Once = Once::new();
+    INSTALL_HOOK.call_once(|| {
+        let previous = panic::take_hook();
+        panic::set_hook(Box::new(move |info| {
+            if !SILENCE_PANICS.with(|silence| silence.get()) {
+                previous(info);
+            }
+        }));
+    });
+    SILENCE_PANICS.with(|silence| silence.set(true));
+    let result = panic::catch_unwind(AssertUnwindSafe(f));
+    SILENCE_PANICS.with(|silence| silence.set(false));
+    result
+}
+
+// CoreBPE::decode panics on ids that are not part of the encoding. The panic is
+// caught here, since unwinding into Go would abort the process, and the position
+// of the first unknown id is reported through `position`. On success the text is
+// returned through `text` and must be released with free_text.
+#[no_mangle]
+pub extern "C" fn decode(
+    model: *const libc::c_char,
+    model_len: libc::size_t,
+    tokens: *const libc::c_uint,
+    tokens_len: libc::size_t,
+    text: *mut *mut libc::c_char,
+    text_len: *mut libc::size_t,
+    position: *mut libc::size_t,
+) -> libc::c_int {
+    let model = str_from_raw(model, model_len);
+    let bpe = get_bpe_from_model(&model).unwrap();
+    let bpe = bpe.lock();
+    let tokens: &[libc::c_uint] = if tokens_len == 0 {
+        &[]
+    } else {
+        unsafe { std::slice::from_raw_parts(tokens, tokens_len) }
+    };
+    let decode = |tokens: Vec<usize>| catch_silently(|| bpe.decode(tokens));
+    match decode(tokens.iter().map(|&token| token as usize).collect()) {
+        Ok(Ok(decoded)) => {
+            let bytes = decoded.into_bytes().into_boxed_slice();
+            unsafe {
+                *text_len = bytes.len();
+                *text = Box::into_raw(bytes) as *mut libc::c_char;
+            }
+            DECODE_OK
+        }
+        Ok(Err(_)) => DECODE_INVALID_UTF8,
+        Err(_) => match tokens
+            .iter()
+            .position(|&token| decode(vec![token as usize]).is_err())
+        {
+            Some(index) => {
+                unsafe { *position = index };
+                DECODE_INVALID_TOKEN
+            }
+            None => DECODE_FAILED,
+        },
+    }
+}
+
+// Releases a text buffer returned by decode.
 #[no_mangle]
-pub extern "C" fn get_context_size(model: *const libc::c_char) -> libc::c_uint {
-    let model = unsafe { CStr::from_ptr(model).to_str().unwrap() };
-    let size = tiktoken_rs::model::get_context_size(model);
-    size as libc::c_uint
+pub extern "C" fn free_text(text: *mut libc::c_char, len: libc::size_t) {
+    unsafe { drop(Box::from_raw(std::ptr::slice_from_raw_parts_mut(text as *mut u8, len))) };
 }
 
@@ -0,0 +1,124 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToolSchema describes a function that is made available to the model.
+// It mirrors the "function" object of a chat completion request and can be unmarshalled from it.
+type ToolSchema struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Parameters  ToolParameter `json:"parameters"`
+}
+
+// ToolParameter is the subset of JSON Schema that affects the prompt the model sees.
+type ToolParameter struct {
+	Type        string                   `json:"type"`
+	Description string                   `json:"description,omitempty"`
+	Enum        []any                    `json:"enum,omitempty"`
+	Properties  map[string]ToolParameter `json:"properties,omitempty"`
+	Required    []string                 `json:"required,omitempty"`
+	Items       *ToolParameter           `json:"items,omitempty"`
+}
+
+// CountToolTokens estimates the number of prompt tokens consumed by the tool definitions of a request.
+// OpenAI renders the definitions as a TypeScript namespace before tokenizing them; this function
+// reproduces that rendering, based on https://github.com/hmarr/openai-chat-tokens.
+// The result is usually within a few tokens of the reported usage.
+// When the request also has a system message, OpenAI counts 4 tokens fewer.
+// An error is returned if the model is not supported.
+func CountToolTokens(model string, tools []ToolSchema) (int, error) {
+	if !IsModelSupported(model) {
+		return 0, fmt.Errorf("%w: %s", ErrModelNotSupported, model)
+	}
+	if len(tools) == 0 {
+		return 0, nil
+	}
+	return CountTokens(model, formatToolDefinitions(tools)) + 9, nil
+}
+
+func formatToolDefinitions(tools []ToolSchema) string {
+	lines := []string{"namespace functions {", ""}
+	for _, tool := range tools {
+		if tool.Description != "" {
+			lines = append(lines, "// "+tool.Description)
+		}
+		if len(tool.Parameters.Properties) > 0 {
+			lines = append(lines, "type "+tool.Name+" = (_: {")
+			lines = append(lines, formatToolProperties(tool.Parameters, 0))
+			lines = append(lines, "}) => any;")
+		} else {
+			lines = append(lines, "type "+tool.Name+" = () => any;")
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, "} // namespace functions")
+	return strings.Join(lines, "\n")
+}
+
+// formatToolProperties renders properties in name order, since Go maps do not keep the schema's order.
+func formatToolProperties(param ToolParameter, indent int) string {
+	names := make([]string, 0, len(param.Properties))
+	for name := range param.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		prop := param.Properties[name]
+		if prop.Description != "" && indent < 2 {
+			lines = append(lines, "// "+prop.Description)
+		}
+		optional := "?"
+		for _, required := range param.Required {
+			if required == name {
+				optional = ""
+				break
+			}
+		}
+		lines = append(lines, name+optional+": "+formatToolType(prop, indent)+",")
+	}
+
+	pad := strings.Repeat(" ", indent)
+	for i := range lines {
+		lines[i] = pad + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatToolType(param ToolParameter, indent int) string {
+	switch param.Type {
+	case "string", "number", "integer":
+		if len(param.Enum) == 0 {
+			if param.Type == "string" {
+				return "string"
+			}
+			return "number"
+		}
+		values := make([]string, len(param.Enum))
+		for i, v := range param.Enum {
+			values[i] = fmt.Sprint(v)
+			if param.Type == "string" {
+				values[i] = `"` + values[i] + `"`
+			}
+		}
+		return strings.Join(values, " | ")
+	case "boolean", "null":
+		return param.Type
+	case "object":
+		return "{\n" + formatToolProperties(param, indent+2) + "\n}"
+	case "array":
+		if param.Items != nil {
+			return formatToolType(*param.Items, indent) + "[]"
+		}
+		return "any[]"
+	default:
+		return ""
+	}
+}
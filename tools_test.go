@@ -0,0 +1,99 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestFormatToolDefinitions(t *testing.T) {
+	tools := []ToolSchema{
+		{
+			Name:        "get_current_weather",
+			Description: "Get the current weather in a given location",
+			Parameters: ToolParameter{
+				Type: "object",
+				Properties: map[string]ToolParameter{
+					"location": {Type: "string", Description: "The city and state, e.g. San Francisco, CA"},
+					"unit":     {Type: "string", Enum: []any{"celsius", "fahrenheit"}},
+					"days":     {Type: "array", Items: &ToolParameter{Type: "integer"}},
+					"hours":    {Type: "integer", Enum: []any{12, 24}},
+					"code":     {Type: "string", Enum: []any{1, 2}},
+				},
+				Required: []string{"location"},
+			},
+		},
+		{
+			Name: "get_time",
+		},
+	}
+
+	want := `namespace functions {
+
+// Get the current weather in a given location
+type get_current_weather = (_: {
+code?: "1" | "2",
+days?: number[],
+hours?: 12 | 24,
+// The city and state, e.g. San Francisco, CA
+location: string,
+unit?: "celsius" | "fahrenheit",
+}) => any;
+
+type get_time = () => any;
+
+} // namespace functions`
+	if got := formatToolDefinitions(tools); got != want {
+		t.Errorf("formatToolDefinitions() = %q, want %q", got, want)
+	}
+}
+
+func TestCountToolTokens(t *testing.T) {
+	if count, err := CountToolTokens("gpt-3.5-turbo", nil); count != 0 || err != nil {
+		t.Errorf("CountToolTokens() = %v, %v, want %v", count, err, 0)
+	}
+	if _, err := CountToolTokens("no-such-model", []ToolSchema{{Name: "foo"}}); !errors.Is(err, ErrModelNotSupported) {
+		t.Errorf("CountToolTokens() error = %v, want ErrModelNotSupported", err)
+	}
+
+	// Prompt token counts reported by the OpenAI API for a single "hello" user message with these
+	// functions, from the hmarr/openai-chat-tokens test fixtures.
+	var testcases = []struct {
+		Tools  []ToolSchema
+		Tokens int
+	}{
+		{
+			[]ToolSchema{{Name: "foo", Parameters: ToolParameter{Type: "object"}}},
+			31,
+		},
+		{
+			[]ToolSchema{{Name: "foo", Description: "Do a foo", Parameters: ToolParameter{Type: "object"}}},
+			36,
+		},
+		{
+			[]ToolSchema{{
+				Name:        "bing_bong",
+				Description: "Do a bing bong",
+				Parameters: ToolParameter{
+					Type:       "object",
+					Properties: map[string]ToolParameter{"foo": {Type: "string"}},
+				},
+			}},
+			49,
+		},
+	}
+
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hello"}}
+	for _, tc := range testcases {
+		count, err := CountToolTokens("gpt-4", tc.Tools)
+		if err != nil {
+			t.Fatalf("CountToolTokens() error = %v", err)
+		}
+		if total := CountMessagesTokens("gpt-4", messages) + count; total != tc.Tokens {
+			t.Errorf("CountToolTokens(%q) + messages = %v, want %v", tc.Tools[0].Name, total, tc.Tokens)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// InvalidUTF8Error is returned by EncodeStrict when the prompt is not valid UTF-8.
+type InvalidUTF8Error struct {
+	// Offset is the byte offset of the first invalid byte.
+	Offset int
+}
+
+func (e *InvalidUTF8Error) Error() string {
+	return fmt.Sprintf("invalid UTF-8 at byte offset %d", e.Offset)
+}
+
+// EncodeStrict is like EncodeWithSpecialTokens, but returns an *InvalidUTF8Error instead of
+// replacing invalid UTF-8, including encoded lone surrogates (U+D800 to U+DFFF), with U+FFFD.
+// An error is also returned if the model is not supported.
+func EncodeStrict(model, prompt string) ([]int, error) {
+	if !IsModelSupported(model) {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotSupported, model)
+	}
+	for i := 0; i < len(prompt); {
+		r, size := utf8.DecodeRuneInString(prompt[i:])
+		if r == utf8.RuneError && size == 1 {
+			return nil, &InvalidUTF8Error{Offset: i}
+		}
+		i += size
+	}
+	return EncodeWithSpecialTokens(model, prompt), nil
+}
+
+// ControlCharacterError is returned by ValidateInput when the input contains disallowed control characters.
+type ControlCharacterError struct {
+	// Offset is the byte offset of the first disallowed character.
+	Offset int
+	// Chars lists each disallowed character found, in order of first appearance.
+	Chars []rune
+}
+
+func (e *ControlCharacterError) Error() string {
+	chars := make([]string, len(e.Chars))
+	for i, c := range e.Chars {
+		chars[i] = fmt.Sprintf("%U", c)
+	}
+	return fmt.Sprintf("disallowed control characters %s, first at byte offset %d", strings.Join(chars, ", "), e.Offset)
+}
+
+// ValidateInput checks input for control characters that some APIs reject, such as NUL.
+// If disallowed is empty, the C0 control characters other than tab, newline and carriage return are disallowed.
+// It returns a *ControlCharacterError describing any characters found.
+func ValidateInput(input string, disallowed ...rune) error {
+	isDisallowed := isDefaultDisallowed
+	if len(disallowed) > 0 {
+		isDisallowed = func(r rune) bool {
+			for _, d := range disallowed {
+				if r == d {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	var err *ControlCharacterError
+	for i, r := range input {
+		if !isDisallowed(r) {
+			continue
+		}
+		if err == nil {
+			err = &ControlCharacterError{Offset: i}
+		}
+		seen := false
+		for _, c := range err.Chars {
+			if c == r {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			err.Chars = append(err.Chars, r)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func isDefaultDisallowed(r rune) bool {
+	return r < 0x20 && r != '\t' && r != '\n' && r != '\r'
+}
@@ -0,0 +1,71 @@
+//go:build !windows
+
+package tiktoken_go
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateInput(t *testing.T) {
+	if err := ValidateInput("hello\tworld\r\n"); err != nil {
+		t.Errorf("ValidateInput() = %v, want nil", err)
+	}
+
+	var testcases = []struct {
+		Input      string
+		Disallowed []rune
+		Offset     int
+		Chars      []rune
+	}{
+		{"hello\x00world", nil, 5, []rune{0}},
+		{"\x1b[0m\x00\x1b", nil, 0, []rune{0x1b, 0}},
+		{"héllo\x07", nil, 6, []rune{7}},
+		{"a\tb", []rune{'\t'}, 1, []rune{'\t'}},
+	}
+
+	for _, tc := range testcases {
+		err := ValidateInput(tc.Input, tc.Disallowed...)
+		var cerr *ControlCharacterError
+		if !errors.As(err, &cerr) {
+			t.Errorf("ValidateInput(%q) = %v, want *ControlCharacterError", tc.Input, err)
+			continue
+		}
+		if cerr.Offset != tc.Offset || !reflect.DeepEqual(cerr.Chars, tc.Chars) {
+			t.Errorf("ValidateInput(%q) = %+v, want offset %v and chars %v", tc.Input, cerr, tc.Offset, tc.Chars)
+		}
+	}
+}
+
+func TestEncodeStrict(t *testing.T) {
+	if _, err := EncodeStrict("gpt-3.5-turbo", "héllo 🧑‍🚀"); err != nil {
+		t.Errorf("EncodeStrict() error = %v, want nil", err)
+	}
+
+	var testcases = []struct {
+		Prompt string
+		Offset int
+	}{
+		// U+D800 encoded as if it were a valid code point.
+		{"hello \xed\xa0\x80", 6},
+		{"\xffhello", 0},
+		{"hé\xc3", 3},
+	}
+
+	for _, tc := range testcases {
+		_, err := EncodeStrict("gpt-3.5-turbo", tc.Prompt)
+		var uerr *InvalidUTF8Error
+		if !errors.As(err, &uerr) {
+			t.Errorf("EncodeStrict(%q) error = %v, want *InvalidUTF8Error", tc.Prompt, err)
+			continue
+		}
+		if uerr.Offset != tc.Offset {
+			t.Errorf("EncodeStrict(%q) error offset = %v, want %v", tc.Prompt, uerr.Offset, tc.Offset)
+		}
+	}
+
+	if _, err := EncodeStrict("no-such-model", "hello"); !errors.Is(err, ErrModelNotSupported) {
+		t.Errorf("EncodeStrict() error = %v, want ErrModelNotSupported", err)
+	}
+}